@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/disintegration/imaging"
+)
+
+var (
+	inProgress   = map[string]struct{}{}
+	inProgressMu sync.Mutex
+)
+
+// writeAtomic writes to targetPath+".tmp" via write, then renames it into
+// place on success. targetPath is tracked as in-progress for the duration so
+// a SIGINT/SIGTERM handler can remove the partial file if the run is
+// cancelled mid-write.
+func writeAtomic(targetPath string, write func(tmpPath string) error) error {
+
+	tmpPath := targetPath + ".tmp"
+
+	inProgressMu.Lock()
+	inProgress[targetPath] = struct{}{}
+	inProgressMu.Unlock()
+
+	defer func() {
+		inProgressMu.Lock()
+		delete(inProgress, targetPath)
+		inProgressMu.Unlock()
+	}()
+
+	if err := write(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, targetPath)
+}
+
+// saveImageAtomic encodes img to targetPath via the tmp+rename dance in
+// writeAtomic. It encodes explicitly from targetPath's extension rather than
+// calling imaging.Save on the ".tmp" path, since imaging.Save infers the
+// format from the filename extension.
+func saveImageAtomic(img image.Image, targetPath string) error {
+
+	format, err := imaging.FormatFromExtension(filepath.Ext(targetPath))
+	if err != nil {
+		return fmt.Errorf("error determining image format for %s: %s", targetPath, err.Error())
+	}
+
+	return writeAtomic(targetPath, func(tmpPath string) error {
+		f, err := os.Create(tmpPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return imaging.Encode(f, img, format)
+	})
+}
+
+// cleanupInProgress removes the partial ".tmp" file for every target still
+// being written, called once on SIGINT/SIGTERM before the process exits.
+func cleanupInProgress() {
+
+	inProgressMu.Lock()
+	defer inProgressMu.Unlock()
+
+	for targetPath := range inProgress {
+		os.Remove(targetPath + ".tmp")
+	}
+}
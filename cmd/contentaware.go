@@ -0,0 +1,371 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+
+	"gomire/internal/utils"
+)
+
+// faceProtectWeight is added to the energy of pixels inside a detected face
+// (tapering off per faceBlurSigma), making seam carving strongly prefer to
+// remove seams everywhere else first.
+const faceProtectWeight = 1e4
+
+// energyBand is how many columns on either side of a just-removed seam get
+// their energy recomputed. Removing a seam only changes which pixels are
+// horizontal/vertical neighbors of each other within a couple of columns of
+// where it was removed, so only that narrow band can have gone stale.
+const energyBand = 2
+
+// resizeContentAware performs a seam-carving resize of srcImg down to
+// targetWidth x targetHeight, biasing seam removal away from any faces
+// detected via facefinderPath, and writes the result to fo.targetPath.
+// Seam carving only removes content, so it can't enlarge either dimension;
+// the caller falls back to a plain Lanczos resize when this returns an
+// error. ctx is checked once per seam so a cancelled run aborts promptly
+// instead of running to completion.
+func resizeContentAware(ctx context.Context, fo FileOperation, srcImg image.Image, targetWidth, targetHeight int) error {
+
+	b := srcImg.Bounds()
+	srcWidth, srcHeight := b.Dx(), b.Dy()
+
+	if targetWidth > srcWidth || targetHeight > srcHeight {
+		return fmt.Errorf("seam carving can only shrink an image, not enlarge it")
+	}
+
+	protect, err := faceProtectionMask(fo.originPath, srcWidth, srcHeight)
+	if err != nil && verbose {
+		fmt.Printf("face detection for %s failed, seam carving without face protection: %s\n", fo.originPath, err.Error())
+	}
+
+	state := newSeamCarveState(srcImg, protect)
+
+	if removeCols := srcWidth - targetWidth; removeCols > 0 {
+		if err := state.carveSeams(ctx, removeCols, false); err != nil {
+			return err
+		}
+	}
+
+	if removeRows := srcHeight - targetHeight; removeRows > 0 {
+		if err := state.carveSeams(ctx, removeRows, true); err != nil {
+			return err
+		}
+	}
+
+	if err := saveImageAtomic(state.toImage(), fo.targetPath); err != nil {
+		return fmt.Errorf("error saving seam-carved image %s: %s", fo.targetPath, err.Error())
+	}
+
+	return nil
+}
+
+// faceProtectionMask returns a protect bias grid shaped like the source
+// image, zero everywhere except a gaussian falloff around each face
+// detected in originPath.
+func faceProtectionMask(originPath string, width, height int) ([][]float64, error) {
+
+	protect := make([][]float64, height)
+	for y := range protect {
+		protect[y] = make([]float64, width)
+	}
+
+	if utils.IsStringEmpty(facefinderPath) {
+		return protect, nil
+	}
+
+	faces, err := detectFaceRects(originPath)
+	if err != nil {
+		return protect, err
+	}
+
+	applyFaceProtection(protect, faces, faceBlurSigma)
+
+	return protect, nil
+}
+
+// applyFaceProtection adds faceProtectWeight to protect for every pixel
+// inside each face rectangle, tapering off over sigma pixels beyond its
+// edge. A sigma of 0 protects exactly the detected rectangle with no
+// falloff.
+func applyFaceProtection(protect [][]float64, faces []image.Rectangle, sigma float64) {
+
+	height := len(protect)
+
+	for _, r := range faces {
+		cx := float64(r.Min.X+r.Max.X) / 2
+		cy := float64(r.Min.Y+r.Max.Y) / 2
+		rx := float64(r.Dx()) / 2
+		ry := float64(r.Dy()) / 2
+
+		margin := int(math.Ceil(sigma * 3))
+
+		minY := max(r.Min.Y-margin, 0)
+		maxY := min(r.Max.Y+margin, height)
+
+		for y := minY; y < maxY; y++ {
+			width := len(protect[y])
+			minX := max(r.Min.X-margin, 0)
+			maxX := min(r.Max.X+margin, width)
+
+			for x := minX; x < maxX; x++ {
+				dx := math.Max(0, math.Abs(float64(x)-cx)-rx)
+				dy := math.Max(0, math.Abs(float64(y)-cy)-ry)
+				dist := math.Hypot(dx, dy)
+
+				weight := 1.0
+				switch {
+				case sigma > 0:
+					weight = math.Exp(-(dist * dist) / (2 * sigma * sigma))
+				case dist > 0:
+					weight = 0
+				}
+
+				protect[y][x] += faceProtectWeight * weight
+			}
+		}
+	}
+}
+
+// seamCarveState holds a source image as flat, row-major pixel/energy
+// buffers so seam removal can work directly on memory instead of going
+// through image.Image's At()/Set() interface calls on every pixel, and so
+// that removing a seam only has to recompute energy in a narrow band
+// around it instead of the whole image.
+type seamCarveState struct {
+	width, height int
+	rgba          []uint8   // len width*height*4, NRGBA
+	lum           []float64 // len width*height
+	energy        []float64 // len width*height, gradient magnitude + protect bias
+	protect       []float64 // len width*height, face-protection bias
+}
+
+func newSeamCarveState(img image.Image, protect [][]float64) *seamCarveState {
+
+	nrgba := imaging.Clone(img)
+	w, h := nrgba.Bounds().Dx(), nrgba.Bounds().Dy()
+
+	flatProtect := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		copy(flatProtect[y*w:(y+1)*w], protect[y])
+	}
+
+	s := &seamCarveState{
+		width:   w,
+		height:  h,
+		rgba:    nrgba.Pix,
+		lum:     make([]float64, w*h),
+		energy:  make([]float64, w*h),
+		protect: flatProtect,
+	}
+
+	for i := 0; i < w*h; i++ {
+		r := float64(s.rgba[i*4])
+		g := float64(s.rgba[i*4+1])
+		b := float64(s.rgba[i*4+2])
+		s.lum[i] = 0.299*r + 0.587*g + 0.114*b
+	}
+
+	s.recomputeEnergyBand(0, w)
+
+	return s
+}
+
+// recomputeEnergyBand recomputes energy[y][x] for every row and every
+// column in [minX, maxX), clamped to the buffer's current width.
+func (s *seamCarveState) recomputeEnergyBand(minX, maxX int) {
+
+	minX = max(minX, 0)
+	maxX = min(maxX, s.width)
+
+	for y := 0; y < s.height; y++ {
+		row := y * s.width
+		upRow := max(y-1, 0) * s.width
+		downRow := min(y+1, s.height-1) * s.width
+
+		for x := minX; x < maxX; x++ {
+			left := s.lum[row+max(x-1, 0)]
+			right := s.lum[row+min(x+1, s.width-1)]
+			up := s.lum[upRow+x]
+			down := s.lum[downRow+x]
+
+			dx := right - left
+			dy := down - up
+			s.energy[row+x] = math.Sqrt(dx*dx+dy*dy) + s.protect[row+x]
+		}
+	}
+}
+
+// minEnergySeam finds the top-to-bottom path of single pixels per row,
+// each horizontally adjacent to the previous, with the lowest total
+// energy, via dynamic programming.
+func (s *seamCarveState) minEnergySeam() []int {
+
+	return minEnergySeamFlat(s.energy, s.width, s.height)
+}
+
+// minEnergySeamFlat is the pure DP underlying minEnergySeam, split out so
+// it can be unit tested against hand-built energy maps without needing an
+// actual image.
+func minEnergySeamFlat(energy []float64, width, height int) []int {
+
+	dp := make([]float64, width*height)
+	back := make([]int, width*height)
+	copy(dp[:width], energy[:width])
+
+	for y := 1; y < height; y++ {
+		row := y * width
+		prevRow := (y - 1) * width
+
+		for x := 0; x < width; x++ {
+			best := dp[prevRow+x]
+			bestX := x
+
+			if x > 0 && dp[prevRow+x-1] < best {
+				best = dp[prevRow+x-1]
+				bestX = x - 1
+			}
+			if x < width-1 && dp[prevRow+x+1] < best {
+				best = dp[prevRow+x+1]
+				bestX = x + 1
+			}
+
+			dp[row+x] = energy[row+x] + best
+			back[row+x] = bestX
+		}
+	}
+
+	seam := make([]int, height)
+	lastRow := (height - 1) * width
+	minX := 0
+	for x := 1; x < width; x++ {
+		if dp[lastRow+x] < dp[lastRow+minX] {
+			minX = x
+		}
+	}
+	seam[height-1] = minX
+
+	for y := height - 2; y >= 0; y-- {
+		seam[y] = back[(y+1)*width+seam[y+1]]
+	}
+
+	return seam
+}
+
+// removeSeam drops the pixel named by seam[y] from each row y, shrinking
+// the buffers by one column, then recomputes energy in the narrow band
+// around the seam that removal could have disturbed.
+func (s *seamCarveState) removeSeam(seam []int) {
+
+	newWidth := s.width - 1
+
+	newRGBA := make([]uint8, newWidth*s.height*4)
+	newLum := make([]float64, newWidth*s.height)
+	newEnergy := make([]float64, newWidth*s.height)
+	newProtect := make([]float64, newWidth*s.height)
+
+	for y := 0; y < s.height; y++ {
+		skip := seam[y]
+		srcRow := y * s.width
+		dstRow := y * newWidth
+
+		dstX := 0
+		for x := 0; x < s.width; x++ {
+			if x == skip {
+				continue
+			}
+
+			dst := dstRow + dstX
+			src := srcRow + x
+			copy(newRGBA[dst*4:dst*4+4], s.rgba[src*4:src*4+4])
+			newLum[dst] = s.lum[src]
+			newEnergy[dst] = s.energy[src]
+			newProtect[dst] = s.protect[src]
+
+			dstX++
+		}
+	}
+
+	s.width = newWidth
+	s.rgba = newRGBA
+	s.lum = newLum
+	s.energy = newEnergy
+	s.protect = newProtect
+
+	minSeam, maxSeam := seam[0], seam[0]
+	for _, x := range seam {
+		minSeam = min(minSeam, x)
+		maxSeam = max(maxSeam, x)
+	}
+	s.recomputeEnergyBand(minSeam-energyBand, maxSeam+energyBand+1)
+}
+
+// transpose swaps the buffer's x and y axes, letting carveSeams reuse its
+// vertical-seam logic to remove horizontal seams.
+func (s *seamCarveState) transpose() {
+
+	w, h := s.width, s.height
+
+	newRGBA := make([]uint8, w*h*4)
+	newLum := make([]float64, w*h)
+	newEnergy := make([]float64, w*h)
+	newProtect := make([]float64, w*h)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			src := y*w + x
+			dst := x*h + y
+			copy(newRGBA[dst*4:dst*4+4], s.rgba[src*4:src*4+4])
+			newLum[dst] = s.lum[src]
+			newEnergy[dst] = s.energy[src]
+			newProtect[dst] = s.protect[src]
+		}
+	}
+
+	s.width, s.height = h, w
+	s.rgba = newRGBA
+	s.lum = newLum
+	s.energy = newEnergy
+	s.protect = newProtect
+}
+
+// carveSeams removes count minimal-energy seams, oriented vertically
+// (column removal, narrowing the image) or, when horizontal is true,
+// transposed first so the same vertical-seam machinery narrows the height
+// instead. ctx is checked once per seam.
+func (s *seamCarveState) carveSeams(ctx context.Context, count int, horizontal bool) error {
+
+	if horizontal {
+		s.transpose()
+	}
+
+	for i := 0; i < count; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		s.removeSeam(s.minEnergySeam())
+	}
+
+	if horizontal {
+		s.transpose()
+	}
+
+	return nil
+}
+
+// toImage materializes the current buffer state as an *image.NRGBA.
+func (s *seamCarveState) toImage() *image.NRGBA {
+
+	return &image.NRGBA{
+		Pix:    s.rgba,
+		Stride: s.width * 4,
+		Rect:   image.Rect(0, 0, s.width, s.height),
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import "testing"
+
+func TestMinEnergySeamFlatStraightLowEnergyColumn(t *testing.T) {
+
+	// A 3-wide, 4-tall grid where column 1 is cheap and columns 0 and 2 are
+	// expensive everywhere, so the minimal seam should run straight down the
+	// middle column.
+	width, height := 3, 4
+	energy := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		energy[y*width+0] = 10
+		energy[y*width+1] = 1
+		energy[y*width+2] = 10
+	}
+
+	seam := minEnergySeamFlat(energy, width, height)
+
+	if len(seam) != height {
+		t.Fatalf("len(seam) = %d, want %d", len(seam), height)
+	}
+
+	for y, x := range seam {
+		if x != 1 {
+			t.Errorf("seam[%d] = %d, want 1", y, x)
+		}
+	}
+}
+
+func TestMinEnergySeamFlatDiagonalLowEnergyPath(t *testing.T) {
+
+	// Energy is lowest along the diagonal x == y, so the seam should follow
+	// it down, moving one column per row.
+	width, height := 4, 4
+	energy := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x == y {
+				energy[y*width+x] = 0
+			} else {
+				energy[y*width+x] = 100
+			}
+		}
+	}
+
+	seam := minEnergySeamFlat(energy, width, height)
+
+	for y, x := range seam {
+		if x != y {
+			t.Errorf("seam[%d] = %d, want %d", y, x, y)
+		}
+	}
+}
+
+func TestMinEnergySeamFlatSingleColumn(t *testing.T) {
+
+	energy := []float64{1, 2, 3}
+	seam := minEnergySeamFlat(energy, 1, 3)
+
+	for y, x := range seam {
+		if x != 0 {
+			t.Errorf("seam[%d] = %d, want 0", y, x)
+		}
+	}
+}
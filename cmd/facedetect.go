@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+
+	pigo "github.com/esimov/pigo/core"
+)
+
+// detectFaceRects runs the pigo cascade classifier at facefinderPath over
+// the image at path, returning the bounding box of every face it finds.
+// pigo is pure Go with no cgo or GUI dependencies, which keeps this a
+// headless-friendly alternative to heavier face-detection libraries.
+func detectFaceRects(path string) ([]image.Rectangle, error) {
+
+	cascadeFile, err := os.ReadFile(facefinderPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading facefinder classifier %s: %s", facefinderPath, err.Error())
+	}
+
+	classifier, err := pigo.NewPigo().Unpack(cascadeFile)
+	if err != nil {
+		return nil, fmt.Errorf("error unpacking facefinder classifier %s: %s", facefinderPath, err.Error())
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening image %s: %s", path, err.Error())
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image %s: %s", path, err.Error())
+	}
+
+	b := img.Bounds()
+	cascadeParams := pigo.CascadeParams{
+		MinSize:     20,
+		MaxSize:     1000,
+		ShiftFactor: 0.1,
+		ScaleFactor: 1.1,
+		ImageParams: pigo.ImageParams{
+			Pixels: pigo.RgbToGrayscale(img),
+			Rows:   b.Dy(),
+			Cols:   b.Dx(),
+			Dim:    b.Dx(),
+		},
+	}
+
+	dets := classifier.RunCascade(cascadeParams, 0.0)
+	dets = classifier.ClusterDetections(dets, 0.2)
+
+	rects := make([]image.Rectangle, 0, len(dets))
+	for _, d := range dets {
+		if d.Q < 5.0 {
+			continue
+		}
+		half := d.Scale / 2
+		rects = append(rects, image.Rect(d.Col-half, d.Row-half, d.Col+half, d.Row+half))
+	}
+
+	return rects, nil
+}
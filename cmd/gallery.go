@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"image"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+//go:embed assets/index.html.tmpl
+var galleryAssets embed.FS
+
+var galleryIndexTmpl = template.Must(template.ParseFS(galleryAssets, "assets/index.html.tmpl"))
+
+// galleryManifestEntry is the per-source-file record written into
+// manifest.json at the gallery output root.
+type galleryManifestEntry struct {
+	Original  string `json:"original"`
+	Fullsize  string `json:"fullsize"`
+	Thumbnail string `json:"thumbnail"`
+}
+
+type galleryDirEntry struct {
+	Name string
+	Path string
+}
+
+type galleryImageEntry struct {
+	Name         string
+	ThumbnailRel string
+	FullsizeRel  string
+}
+
+type galleryPageData struct {
+	Title  string
+	Dirs   []galleryDirEntry
+	Images []galleryImageEntry
+}
+
+// processGalleryImage writes the _original, _fullsize and _thumbnail tiers
+// for a single source image.
+func processGalleryImage(fo FileOperation) error {
+
+	relPath := galleryRelPath(fo.originPath)
+
+	srcImg, err := imaging.Open(fo.originPath)
+	if err != nil {
+		return fmt.Errorf("error opening image %s: %s", fo.originPath, err.Error())
+	}
+
+	b := srcImg.Bounds()
+
+	originalPath := filepath.Join(outputDir, "_original", relPath)
+	if err := copyGalleryOriginal(fo.originPath, originalPath); err != nil {
+		return err
+	}
+
+	fw, fh := parseResolution(galleryFullsizeRes)
+	fw, fh = resolveTargetDimensions(b.Dx(), b.Dy(), fw, fh)
+	fullsizePath := galleryTierPath(outputDir, "_fullsize", relPath)
+	if err := saveGalleryTier(srcImg, fullsizePath, fw, fh); err != nil {
+		return err
+	}
+
+	tw, th := parseResolution(galleryThumbnailRes)
+	tw, th = resolveTargetDimensions(b.Dx(), b.Dy(), tw, th)
+	thumbnailPath := galleryTierPath(outputDir, "_thumbnail", relPath)
+	if err := saveGalleryTier(srcImg, thumbnailPath, tw, th); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// galleryRelPath returns originPath relative to inputDir, using forward
+// slashes so it can also serve as an HTML link path.
+func galleryRelPath(originPath string) string {
+
+	rel := strings.TrimPrefix(originPath, inputDir)
+	rel = strings.TrimPrefix(rel, string(os.PathSeparator))
+	return filepath.ToSlash(rel)
+}
+
+// galleryTierPath maps a source-relative path to its .jpg path within a
+// gallery tier directory.
+func galleryTierPath(outputDir, tier, relPath string) string {
+
+	ext := filepath.Ext(relPath)
+	base := strings.TrimSuffix(relPath, ext)
+	return filepath.Join(outputDir, filepath.FromSlash(tier), filepath.FromSlash(base)+".jpg")
+}
+
+func copyGalleryOriginal(src, dst string) error {
+
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModeDir); err != nil {
+		return fmt.Errorf("error creating destination folder %s: %s", dst, err.Error())
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("error opening image %s: %s", src, err.Error())
+	}
+	defer in.Close()
+
+	err = writeAtomic(dst, func(tmpPath string) error {
+		out, err := os.Create(tmpPath)
+		if err != nil {
+			return fmt.Errorf("error creating file %s: %s", dst, err.Error())
+		}
+		defer out.Close()
+
+		_, err = io.Copy(out, in)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error copying original image to %s: %s", dst, err.Error())
+	}
+
+	return nil
+}
+
+func saveGalleryTier(srcImg image.Image, dst string, w, h int) error {
+
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModeDir); err != nil {
+		return fmt.Errorf("error creating destination folder %s: %s", dst, err.Error())
+	}
+
+	dstImg := image.Image(srcImg)
+	if !alreadySmallerThanTarget(srcImg, w, h) {
+		dstImg = imaging.Fit(srcImg, w, h, imaging.Lanczos)
+	}
+
+	if err := saveImageAtomic(dstImg, dst); err != nil {
+		return fmt.Errorf("error saving gallery tier %s: %s", dst, err.Error())
+	}
+
+	return nil
+}
+
+// generateGalleryIndex renders index.html per directory of the processed
+// tree plus a top-level manifest.json, once the worker pool has finished.
+func generateGalleryIndex(outputDir string, files []FileOperation) error {
+
+	byDir := map[string][]FileOperation{}
+	manifest := map[string]galleryManifestEntry{}
+
+	for _, fo := range files {
+		if fo.kind != kindImage {
+			continue
+		}
+
+		relPath := galleryRelPath(fo.originPath)
+		dir := path.Dir(relPath)
+		byDir[dir] = append(byDir[dir], fo)
+
+		manifest[relPath] = galleryManifestEntry{
+			Original:  path.Join("_original", relPath),
+			Fullsize:  path.Join("_fullsize", strings.TrimSuffix(relPath, path.Ext(relPath))+".jpg"),
+			Thumbnail: path.Join("_thumbnail", strings.TrimSuffix(relPath, path.Ext(relPath))+".jpg"),
+		}
+	}
+
+	for dir := range byDir {
+		for d := path.Dir(dir); d != "." && d != "/"; d = path.Dir(d) {
+			if _, ok := byDir[d]; !ok {
+				byDir[d] = nil
+			}
+		}
+	}
+	if _, ok := byDir["."]; !ok {
+		byDir["."] = nil
+	}
+
+	for dir, entries := range byDir {
+		if err := renderGalleryDirIndex(outputDir, dir, entries, byDir); err != nil {
+			return err
+		}
+	}
+
+	return writeGalleryManifest(outputDir, manifest)
+}
+
+func renderGalleryDirIndex(outputDir, dir string, entries []FileOperation, byDir map[string][]FileOperation) error {
+
+	var subdirs []galleryDirEntry
+	for candidate := range byDir {
+		if candidate == dir || path.Dir(candidate) != dir {
+			continue
+		}
+		subdirs = append(subdirs, galleryDirEntry{
+			Name: path.Base(candidate),
+			Path: path.Join(path.Base(candidate), "index.html"),
+		})
+	}
+	sort.Slice(subdirs, func(i, j int) bool { return subdirs[i].Name < subdirs[j].Name })
+
+	toRoot := climbToGalleryRoot(dir)
+
+	var images []galleryImageEntry
+	for _, fo := range entries {
+		relPath := galleryRelPath(fo.originPath)
+		base := strings.TrimSuffix(path.Base(relPath), path.Ext(relPath)) + ".jpg"
+		images = append(images, galleryImageEntry{
+			Name:         path.Base(relPath),
+			ThumbnailRel: toRoot + path.Join("_thumbnail", dir, base),
+			FullsizeRel:  toRoot + path.Join("_fullsize", dir, base),
+		})
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Name < images[j].Name })
+
+	title := dir
+	if title == "." {
+		title = "Gallery"
+	}
+
+	data := galleryPageData{Title: title, Dirs: subdirs, Images: images}
+
+	indexDir := filepath.Join(outputDir, filepath.FromSlash(dir))
+	if err := os.MkdirAll(indexDir, os.ModeDir); err != nil {
+		return fmt.Errorf("error creating gallery directory %s: %s", indexDir, err.Error())
+	}
+
+	f, err := os.Create(filepath.Join(indexDir, "index.html"))
+	if err != nil {
+		return fmt.Errorf("error creating gallery index for %s: %s", dir, err.Error())
+	}
+	defer f.Close()
+
+	if err := galleryIndexTmpl.Execute(f, data); err != nil {
+		return fmt.Errorf("error rendering gallery index for %s: %s", dir, err.Error())
+	}
+
+	return nil
+}
+
+// climbToGalleryRoot returns the "../" prefix needed to climb from dir back
+// up to the gallery output root, so tier links work regardless of nesting
+// depth.
+func climbToGalleryRoot(dir string) string {
+
+	if dir == "." {
+		return ""
+	}
+	return strings.Repeat("../", strings.Count(dir, "/")+1)
+}
+
+func writeGalleryManifest(outputDir string, manifest map[string]galleryManifestEntry) error {
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding manifest.json: %s", err.Error())
+	}
+
+	if err := os.WriteFile(filepath.Join(outputDir, "manifest.json"), b, 0644); err != nil {
+		return fmt.Errorf("error writing manifest.json: %s", err.Error())
+	}
+
+	return nil
+}
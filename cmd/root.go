@@ -1,16 +1,26 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"gomire/internal/utils"
+	"image"
 	"io/fs"
+	"math"
 	"math/rand"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/disintegration/imaging"
@@ -18,15 +28,36 @@ import (
 	"github.com/spf13/cobra"
 )
 
+type fileKind string
+
+const (
+	kindImage fileKind = "image"
+	kindVideo fileKind = "video"
+)
+
 var (
-	inputDir          string
-	outputDir         string
-	recursive         bool
-	verbose           bool
-	fileType          string
-	fileTypes         []string
-	resolution        string
-	supportedImgTypes = []string{"jpg", "png", "gif", "tif", "bmp"}
+	inputDir            string
+	outputDir           string
+	recursive           bool
+	verbose             bool
+	includePatterns     []string
+	excludePatterns     []string
+	resolution          string
+	resizeMode          string
+	facefinderPath      string
+	faceBlurSigma       float64
+	fitMode             string
+	ffmpegPath          string
+	galleryMode         bool
+	galleryFullsizeRes  string
+	galleryThumbnailRes string
+	syncMode            bool
+	cleanupMode         bool
+	jobs                int
+	supportedImgTypes   = []string{"jpg", "png", "gif", "tif", "bmp"}
+	supportedVidTypes   = []string{"mp4", "mov", "mkv", "webm", "avi"}
+	supportedModes      = []string{"lanczos", "content-aware"}
+	supportedFitModes   = []string{"cover", "contain", "stretch"}
 )
 
 type FileOperation struct {
@@ -34,23 +65,33 @@ type FileOperation struct {
 	targetPath   string
 	targetWidth  int
 	targetHeight int
+	kind         fileKind
 }
 
-func NewFileOperation(o_path string, t_path string, res string) *FileOperation {
+// outputPaths returns the file(s) fo actually produces: its single resized
+// copy normally, or the _original/_fullsize/_thumbnail tier paths for an
+// image when --gallery is active (gallery mode never writes an image to
+// fo.targetPath; videos still get their single transcoded copy there).
+func outputPaths(fo FileOperation) []string {
 
-	splits := strings.Split(res, "x")
-	w, err := strconv.Atoi(splits[0])
+	if !galleryMode || fo.kind != kindImage {
+		return []string{fo.targetPath}
+	}
 
-	if err != nil {
-		fmt.Printf("Unable to parse width from %s: %e", res, err)
-		os.Exit(2)
+	relPath := galleryRelPath(fo.originPath)
+	return []string{
+		filepath.Join(outputDir, "_original", relPath),
+		galleryTierPath(outputDir, "_fullsize", relPath),
+		galleryTierPath(outputDir, "_thumbnail", relPath),
 	}
+}
 
-	h, err := strconv.Atoi(splits[1])
+func NewFileOperation(o_path string, t_path string, res string, kind fileKind) *FileOperation {
 
-	if err != nil {
-		fmt.Printf("Unable to parse height from %s: %e", res, err)
-		os.Exit(2)
+	w, h := parseResolution(res)
+
+	if kind == kindVideo {
+		t_path = strings.TrimSuffix(t_path, filepath.Ext(t_path)) + ".mp4"
 	}
 
 	return &FileOperation{
@@ -58,7 +99,68 @@ func NewFileOperation(o_path string, t_path string, res string) *FileOperation {
 		targetPath:   t_path,
 		targetWidth:  w,
 		targetHeight: h,
+		kind:         kind,
+	}
+}
+
+// parseResolution parses a "<width>x<height>" string, exiting the process on
+// a malformed value. Either side may be left blank (e.g. "1920x" or "x1080")
+// to mean "keep aspect ratio", represented here as 0.
+func parseResolution(res string) (int, int) {
+
+	splits := strings.SplitN(res, "x", 2)
+
+	w := parseResolutionDimension(splits[0], res, "width")
+
+	h := 0
+	if len(splits) > 1 {
+		h = parseResolutionDimension(splits[1], res, "height")
+	}
+
+	if w == 0 && h == 0 {
+		fmt.Printf("Invalid resolution %q: at least one of width/height must be given\n", res)
+		os.Exit(2)
+	}
+
+	return w, h
+}
+
+func parseResolutionDimension(s, res, label string) int {
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		fmt.Printf("Unable to parse %s from %s: %s\n", label, res, err.Error())
+		os.Exit(2)
+	}
+
+	if v <= 0 {
+		fmt.Printf("Invalid %s %d in resolution %s: must be a positive integer, or blank to keep aspect ratio\n", label, v, res)
+		os.Exit(2)
 	}
+
+	return v
+}
+
+// resolveTargetDimensions fills in whichever of targetWidth/targetHeight was
+// left as 0 (meaning "keep aspect ratio") using the source image's aspect
+// ratio. If both are already set, they're returned unchanged.
+func resolveTargetDimensions(srcWidth, srcHeight, targetWidth, targetHeight int) (int, int) {
+
+	switch {
+	case targetWidth == 0 && targetHeight == 0:
+		return srcWidth, srcHeight
+	case targetWidth == 0:
+		targetWidth = int(math.Round(float64(srcWidth) * float64(targetHeight) / float64(srcHeight)))
+	case targetHeight == 0:
+		targetHeight = int(math.Round(float64(srcHeight) * float64(targetWidth) / float64(srcWidth)))
+	}
+
+	return targetWidth, targetHeight
 }
 
 var rootCmd = &cobra.Command{
@@ -82,9 +184,21 @@ func init() {
 	rootCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Find and resize images from subfolders (required)")
 	rootCmd.Flags().StringVarP(&inputDir, "input-dir", "i", "", "Location to the image directory (required)")
 	rootCmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Location to the output directory. Will be created if does not exist (required)")
-	rootCmd.Flags().StringVarP(&fileType, "type", "t", "png,jpg", fmt.Sprintf("Image file type(s) separated by commas. Supported filetypes are %s", strings.Join(supportedImgTypes, ", ")))
+	rootCmd.Flags().StringArrayVar(&includePatterns, "include", []string{"**/*"}, "Glob pattern(s) a file's path (relative to input-dir) must match to be processed, repeatable (default: **/*)")
+	rootCmd.Flags().StringArrayVar(&excludePatterns, "exclude", nil, "Glob pattern(s) that exclude an otherwise-included file, repeatable")
 	rootCmd.Flags().StringVarP(&resolution, "resolution", "R", "", "Target image resolution in <width>x<height> format (e.g 1920x1080) (required)")
 	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Print reasoning for all failed operations")
+	rootCmd.Flags().StringVarP(&resizeMode, "mode", "m", "lanczos", fmt.Sprintf("Resize algorithm to use. One of %s", strings.Join(supportedModes, ", ")))
+	rootCmd.Flags().StringVar(&facefinderPath, "facefinder", "", "Path to the facefinder classifier file, required when --mode=content-aware")
+	rootCmd.Flags().Float64Var(&faceBlurSigma, "face-blur-sigma", 0, "Blur sigma applied to detected faces to bias seam carving away from them (content-aware mode only)")
+	rootCmd.Flags().StringVar(&fitMode, "fit", "cover", fmt.Sprintf("How videos are scaled/padded to the target resolution. One of %s", strings.Join(supportedFitModes, ", ")))
+	rootCmd.Flags().StringVar(&ffmpegPath, "ffmpeg", "", "Path to the ffmpeg binary used for video transcoding (default: looked up on $PATH)")
+	rootCmd.Flags().BoolVar(&galleryMode, "gallery", false, "Produce a static HTML gallery with _original, _fullsize and _thumbnail tiers instead of a single resized copy")
+	rootCmd.Flags().StringVar(&galleryFullsizeRes, "gallery-fullsize", "1920x1920", "Max box size for the _fullsize gallery tier")
+	rootCmd.Flags().StringVar(&galleryThumbnailRes, "gallery-thumbnail", "280x280", "Box size for the _thumbnail gallery tier")
+	rootCmd.Flags().BoolVar(&syncMode, "sync", false, "Skip files whose output is already up to date, tracked via a .gomire-index.json sidecar")
+	rootCmd.Flags().BoolVar(&cleanupMode, "cleanup", false, "After processing, delete files/directories under output-dir that no longer have a counterpart in input-dir")
+	rootCmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "Maximum number of files processed concurrently")
 
 	rootCmd.MarkFlagRequired("input-dir")
 	rootCmd.MarkFlagRequired("output-dir")
@@ -107,7 +221,40 @@ func cmdMain(cmd *cobra.Command, args []string) {
 		os.Exit(0)
 	}
 
+	if syncMode {
+		syncIdx = loadSyncIndex(outputDir)
+	}
+
 	copyFilesWithProgress(files)
+
+	if syncMode {
+		if err := saveSyncIndex(outputDir, syncIdx); err != nil {
+			fmt.Printf("Error saving sync index: %s\n", err.Error())
+			os.Exit(2)
+		}
+	}
+
+	if cleanupMode {
+		keep := make(map[string]bool, len(files))
+		for _, f := range files {
+			for _, p := range outputPaths(f) {
+				keep[p] = true
+			}
+		}
+
+		if err := cleanupOutputDir(outputDir, keep); err != nil {
+			fmt.Printf("Error cleaning up output directory: %s\n", err.Error())
+			os.Exit(2)
+		}
+	}
+
+	if galleryMode {
+		err := generateGalleryIndex(outputDir, files)
+		if err != nil {
+			fmt.Printf("Error generating gallery index: %s\n", err.Error())
+			os.Exit(2)
+		}
+	}
 }
 
 func validateFlags() {
@@ -142,25 +289,18 @@ func validateFlags() {
 	}
 
 	// If output dir exists within input dir
-	if strings.Contains(outputDir, inputDir) {
+	if pathWithinDir(outputDir, inputDir) {
 		fmt.Println("Error: Output directory can't be a sub directory of the input directory")
 		os.Exit(2)
 	}
 
-	fileType = strings.Replace(fileType, "jpeg", "jpg", -1)
-	fileType = strings.Replace(fileType, "tiff", "tif", -1)
-
-	// Format all requested filetypes to be like ".jpg"
-	fileTypes = strings.Split(fileType, ",")
-	for i, v := range fileTypes {
-
-		if utils.IsStringEmpty(v) {
-			continue
-		}
-
-		if v[0] != '.' {
-			fileTypes[i] = fmt.Sprintf(".%s", v)
-		}
+	// Normalize include/exclude patterns to forward slashes so they're
+	// portable across platforms.
+	for i, p := range includePatterns {
+		includePatterns[i] = filepath.ToSlash(p)
+	}
+	for i, p := range excludePatterns {
+		excludePatterns[i] = filepath.ToSlash(p)
 	}
 
 	// Resolution
@@ -171,6 +311,62 @@ func validateFlags() {
 		os.Exit(2)
 	}
 
+	// Resize mode
+	resizeMode = strings.ToLower(strings.TrimSpace(resizeMode))
+	if !slices.Contains(supportedModes, resizeMode) {
+		fmt.Printf("Invalid resize mode %q: supported modes are %s\n", resizeMode, strings.Join(supportedModes, ", "))
+		os.Exit(2)
+	}
+
+	if resizeMode == "content-aware" {
+		if utils.IsStringEmpty(facefinderPath) {
+			fmt.Println("Error: --facefinder is required when --mode=content-aware")
+			os.Exit(2)
+		}
+
+		e, _ = utils.PathExists(facefinderPath)
+		if !e {
+			fmt.Printf("Error: facefinder classifier %s does not exist\n", facefinderPath)
+			os.Exit(2)
+		}
+	}
+
+	// Fit mode
+	fitMode = strings.ToLower(strings.TrimSpace(fitMode))
+	if !slices.Contains(supportedFitModes, fitMode) {
+		fmt.Printf("Invalid fit mode %q: supported modes are %s\n", fitMode, strings.Join(supportedFitModes, ", "))
+		os.Exit(2)
+	}
+
+	// ffmpeg binary
+	if utils.IsStringEmpty(ffmpegPath) {
+		p, err := exec.LookPath("ffmpeg")
+		if err == nil {
+			ffmpegPath = p
+		}
+	}
+
+	// Gallery tier sizes
+	if galleryMode {
+		galleryFullsizeRes = strings.ToLower(strings.TrimSpace(galleryFullsizeRes))
+		galleryThumbnailRes = strings.ToLower(strings.TrimSpace(galleryThumbnailRes))
+
+		if !strings.Contains(galleryFullsizeRes, "x") {
+			fmt.Println("Invalid --gallery-fullsize format: missing \"x\" as a dimensional separator")
+			os.Exit(2)
+		}
+
+		if !strings.Contains(galleryThumbnailRes, "x") {
+			fmt.Println("Invalid --gallery-thumbnail format: missing \"x\" as a dimensional separator")
+			os.Exit(2)
+		}
+	}
+
+	// Concurrency
+	if jobs < 1 {
+		fmt.Println("Error: --jobs must be at least 1")
+		os.Exit(2)
+	}
 }
 
 func listFilesToBeCopies() ([]FileOperation, error) {
@@ -193,9 +389,9 @@ func listFilesToBeCopies() ([]FileOperation, error) {
 			return nil
 		}
 
-		if isSupportedFiletype(filepath.Ext(s)) {
+		if isSupportedFiletype(filepath.Ext(s)) && matchesGlobFilters(relPathForGlob(s)) {
 			t := strings.Replace(s, inputDir, outputDir, 1)
-			files = append(files, *NewFileOperation(s, t, resolution))
+			files = append(files, *NewFileOperation(s, t, resolution, filetypeKind(filepath.Ext(s))))
 		}
 
 		return nil
@@ -204,17 +400,79 @@ func listFilesToBeCopies() ([]FileOperation, error) {
 	return files, nil
 }
 
+// relPathForGlob returns s relative to inputDir, using forward slashes so
+// glob patterns are portable across platforms.
+func relPathForGlob(s string) string {
+
+	rel := strings.TrimPrefix(s, inputDir)
+	rel = strings.TrimPrefix(rel, string(os.PathSeparator))
+	return filepath.ToSlash(rel)
+}
+
+// matchesGlobFilters reports whether relPath matches at least one
+// --include pattern and none of the --exclude patterns.
+func matchesGlobFilters(relPath string) bool {
+
+	included := false
+	for _, p := range includePatterns {
+		if utils.MatchGlob(p, relPath) {
+			included = true
+			break
+		}
+	}
+
+	if !included {
+		return false
+	}
+
+	for _, p := range excludePatterns {
+		if utils.MatchGlob(p, relPath) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pathWithinDir reports whether path is dir itself or a descendant of it.
+func pathWithinDir(path, dir string) bool {
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return rel == "." || (!strings.HasPrefix(rel, "..") && rel != "..")
+}
+
 func isSupportedFiletype(s string) bool {
 
-	return slices.Contains(supportedImgTypes, strings.ToLower(s)[1:])
+	if s == "" {
+		return false
+	}
+
+	ext := strings.ToLower(s)[1:]
+	return slices.Contains(supportedImgTypes, ext) || slices.Contains(supportedVidTypes, ext)
 }
 
-// Loops through all requested files and displays CLI progress bar during the operation
+// filetypeKind returns the FileOperation kind for a file extension already
+// confirmed supported by isSupportedFiletype.
+func filetypeKind(s string) fileKind {
+
+	if slices.Contains(supportedVidTypes, strings.ToLower(s)[1:]) {
+		return kindVideo
+	}
+	return kindImage
+}
+
+// Loops through all requested files and displays CLI progress bar during the operation.
+// Cancellable via SIGINT/SIGTERM: in-flight outputs are tracked so any
+// partially-written target is removed before the process exits.
 func copyFilesWithProgress(fo []FileOperation) {
 
 	bar := progressbar.NewOptions(
 		len(fo),
-		progressbar.OptionSetDescription("Resizing images:"),
+		progressbar.OptionSetDescription("Processing files:"),
 		progressbar.OptionShowCount(),
 		progressbar.OptionSetWidth(-1),
 		progressbar.OptionShowElapsedTimeOnFinish(),
@@ -223,56 +481,273 @@ func copyFilesWithProgress(fo []FileOperation) {
 
 	time.Sleep(500 * time.Millisecond)
 	var errs []string
+	var errsMu sync.Mutex
 	var wg sync.WaitGroup
 	rand.NewSource(time.Now().UnixNano())
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	var interrupted atomic.Bool
+
+	go func() {
+		select {
+		case _, ok := <-sigCh:
+			if !ok {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+
+		interrupted.Store(true)
+		cancel()
+
+		// Wait for in-flight writers to actually notice ctx and stop before
+		// touching their .tmp files, so we don't remove one out from under
+		// a writer that hasn't exited yet. A second signal force-quits
+		// immediately instead of waiting.
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-sigCh:
+			fmt.Println("\nForce quitting, partial outputs may remain")
+			os.Exit(130)
+		}
+
+		cleanupInProgress()
+	}()
+
+	sem := make(chan struct{}, jobs)
+
 	for _, f := range fo {
+		if ctx.Err() != nil {
+			bar.Add(1)
+			continue
+		}
+
 		wg.Add(1)
 
 		go func(fo FileOperation) {
 			// HOX: We can't print out anything before this loop is finished as it messes up the progress bar
 			defer wg.Done()
 			defer bar.Add(1)
-			err := processImage(fo)
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			if syncMode && shouldSkipSync(fo, syncIdx) {
+				return
+			}
+
+			err := processFile(ctx, fo)
 			if err != nil {
-				errs = append(errs, fmt.Sprintf("Error processing image: %s\n", err.Error()))
+				if !errors.Is(err, context.Canceled) {
+					errsMu.Lock()
+					errs = append(errs, fmt.Sprintf("Error processing file: %s\n", err.Error()))
+					errsMu.Unlock()
+				}
+				return
+			}
+
+			if syncMode {
+				recordSyncIndexEntry(fo)
 			}
 		}(f)
 	}
 
 	wg.Wait()
+	bar.Finish()
 
 	// Print out errors occured during copying, after the progressbar has finished
 	if len(errs) > 0 {
-		fmt.Printf("\n%d image operations failed\n", len(errs))
+		fmt.Printf("\n%d operations failed\n", len(errs))
 	}
 	if verbose {
 		for _, e := range errs {
 			fmt.Println(e)
 		}
 	}
+
+	if interrupted.Load() {
+		fmt.Println("\nInterrupted, partial outputs cleaned up")
+		os.Exit(130)
+	}
 }
 
-func processImage(fo FileOperation) error {
+// processFile dispatches a FileOperation to the image or video pipeline
+// depending on its kind.
+func processFile(ctx context.Context, fo FileOperation) error {
+
+	if fo.kind == kindVideo {
+		return processVideo(ctx, fo)
+	}
+
+	if galleryMode {
+		return processGalleryImage(fo)
+	}
+
+	return processImage(ctx, fo)
+}
+
+func processImage(ctx context.Context, fo FileOperation) error {
 
 	// fmt.Printf("Processing image %s\n", fo.originPath)
 
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
 	srcImg, err := imaging.Open(fo.originPath)
 	if err != nil {
 		return fmt.Errorf("error opening image %s: %s", fo.originPath, err.Error())
 	}
 
-	dstImg := imaging.Resize(srcImg, fo.targetWidth, fo.targetHeight, imaging.Lanczos)
-
 	err = os.MkdirAll(filepath.Dir(fo.targetPath), os.ModeDir)
 	if err != nil {
 		return fmt.Errorf("error creating destination folder %s: %s", fo.targetPath, err.Error())
 	}
 
-	err = imaging.Save(dstImg, fo.targetPath)
-	if err != nil {
+	b := srcImg.Bounds()
+	targetWidth, targetHeight := resolveTargetDimensions(b.Dx(), b.Dy(), fo.targetWidth, fo.targetHeight)
+
+	if alreadySmallerThanTarget(srcImg, targetWidth, targetHeight) {
+		if err := saveImageAtomic(srcImg, fo.targetPath); err != nil {
+			return fmt.Errorf("error saving image %s: %s", fo.targetPath, err.Error())
+		}
+		return nil
+	}
+
+	if resizeMode == "content-aware" {
+		err = resizeContentAware(ctx, fo, srcImg, targetWidth, targetHeight)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		if verbose {
+			fmt.Printf("content-aware resize of %s failed, falling back to lanczos: %s\n", fo.originPath, err.Error())
+		}
+	}
+
+	dstImg := imaging.Resize(srcImg, targetWidth, targetHeight, imaging.Lanczos)
+
+	if err := saveImageAtomic(dstImg, fo.targetPath); err != nil {
 		return fmt.Errorf("error saving resized image %s: %s", fo.targetPath, err.Error())
 	}
 
 	return nil
 }
+
+// alreadySmallerThanTarget reports whether img is already at or below the
+// requested target dimensions, in which case resizing would upscale it.
+func alreadySmallerThanTarget(img image.Image, targetWidth, targetHeight int) bool {
+
+	b := img.Bounds()
+	return b.Dx() <= targetWidth && b.Dy() <= targetHeight
+}
+
+// processVideo transcodes fo.originPath to a resized, fit-scaled H.264/AAC
+// .mp4 at fo.targetPath by shelling out to ffmpeg. ctx is wired into the
+// ffmpeg process so a cancelled run kills it instead of leaving it orphaned.
+func processVideo(ctx context.Context, fo FileOperation) error {
+
+	if utils.IsStringEmpty(ffmpegPath) {
+		return fmt.Errorf("ffmpeg binary not found: install ffmpeg or set --ffmpeg")
+	}
+
+	err := os.MkdirAll(filepath.Dir(fo.targetPath), os.ModeDir)
+	if err != nil {
+		return fmt.Errorf("error creating destination folder %s: %s", fo.targetPath, err.Error())
+	}
+
+	vf := videoScaleFilter(fo.targetWidth, fo.targetHeight, fitMode)
+
+	var stderr bytes.Buffer
+
+	err = writeAtomic(fo.targetPath, func(tmpPath string) error {
+		cmd := exec.CommandContext(ctx, ffmpegPath,
+			"-y",
+			"-i", fo.originPath,
+			"-vf", vf,
+			"-c:v", "libx264",
+			"-preset", "medium",
+			"-crf", "23",
+			"-c:a", "aac",
+			"-movflags", "+faststart",
+			"-f", "mp4",
+			tmpPath,
+		)
+		cmd.Stderr = &stderr
+		return cmd.Run()
+	})
+	if err != nil {
+		return fmt.Errorf("error transcoding video %s: %s: %s", fo.originPath, err.Error(), stderr.String())
+	}
+
+	return nil
+}
+
+// videoScaleFilter builds the ffmpeg -vf chain that scales to width x height
+// per the requested fit mode, always producing even dimensions: an odd
+// requested width or height is rounded up by one pixel first. If either
+// dimension is unset (0, meaning "keep aspect ratio"), cover/contain's
+// pad/crop math doesn't apply, so the other dimension is scaled to directly
+// via ffmpeg's own auto-dimension support regardless of fit mode.
+func videoScaleFilter(width, height int, fit string) string {
+
+	if width > 0 && width%2 == 1 {
+		width++
+	}
+	if height > 0 && height%2 == 1 {
+		height++
+	}
+
+	if width <= 0 || height <= 0 {
+		return fmt.Sprintf("scale=%s:%s:force_divisible_by=2,format=yuv420p", dimOrAuto(width), dimOrAuto(height))
+	}
+
+	switch fit {
+	case "stretch":
+		return fmt.Sprintf("scale=%d:%d:force_divisible_by=2,format=yuv420p", width, height)
+	case "contain":
+		return fmt.Sprintf(
+			"scale=%d:%d:force_original_aspect_ratio=decrease:force_divisible_by=2,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,format=yuv420p",
+			width, height, width, height,
+		)
+	default: // cover
+		return fmt.Sprintf(
+			"scale=%d:%d:force_original_aspect_ratio=increase:force_divisible_by=2,crop=%d:%d,format=yuv420p",
+			width, height, width, height,
+		)
+	}
+}
+
+// dimOrAuto returns v as a string, or ffmpeg's "-2" sentinel when v is unset
+// (0), which derives that side from the other dimension while keeping it
+// divisible by 2.
+func dimOrAuto(v int) string {
+
+	if v <= 0 {
+		return "-2"
+	}
+	return strconv.Itoa(v)
+}
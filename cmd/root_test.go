@@ -0,0 +1,50 @@
+package cmd
+
+import "testing"
+
+func TestParseResolution(t *testing.T) {
+
+	cases := []struct {
+		res        string
+		wantWidth  int
+		wantHeight int
+	}{
+		{"1920x1080", 1920, 1080},
+		{"1920x", 1920, 0},
+		{"x1080", 0, 1080},
+	}
+
+	for _, c := range cases {
+		t.Run(c.res, func(t *testing.T) {
+			w, h := parseResolution(c.res)
+			if w != c.wantWidth || h != c.wantHeight {
+				t.Errorf("parseResolution(%q) = (%d, %d), want (%d, %d)", c.res, w, h, c.wantWidth, c.wantHeight)
+			}
+		})
+	}
+}
+
+func TestResolveTargetDimensions(t *testing.T) {
+
+	cases := []struct {
+		name                      string
+		srcWidth, srcHeight       int
+		targetWidth, targetHeight int
+		wantWidth, wantHeight     int
+	}{
+		{"both given", 2000, 1000, 800, 600, 800, 600},
+		{"width only, keep aspect", 2000, 1000, 800, 0, 800, 400},
+		{"height only, keep aspect", 2000, 1000, 0, 400, 800, 400},
+		{"neither given, keep source size", 2000, 1000, 0, 0, 2000, 1000},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w, h := resolveTargetDimensions(c.srcWidth, c.srcHeight, c.targetWidth, c.targetHeight)
+			if w != c.wantWidth || h != c.wantHeight {
+				t.Errorf("resolveTargetDimensions(%d, %d, %d, %d) = (%d, %d), want (%d, %d)",
+					c.srcWidth, c.srcHeight, c.targetWidth, c.targetHeight, w, h, c.wantWidth, c.wantHeight)
+			}
+		})
+	}
+}
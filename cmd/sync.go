@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const syncIndexFilename = ".gomire-index.json"
+
+// syncIndexEntry is the per-source-file record kept in the sidecar index so
+// --sync can tell whether a target is already up to date without re-reading
+// the source on every run.
+type syncIndexEntry struct {
+	SourcePath string    `json:"source_path"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+	SHA256     string    `json:"sha256"`
+	Resolution string    `json:"resolution"`
+}
+
+// syncIndex maps a FileOperation's source path to the metadata recorded the
+// last time it was produced. Keying on the source rather than the target
+// lets --sync work uniformly in --gallery mode, where a single source image
+// produces three tier outputs rather than one target path.
+type syncIndex map[string]syncIndexEntry
+
+var (
+	syncIdx   syncIndex
+	syncIdxMu sync.Mutex
+)
+
+// loadSyncIndex reads the sidecar index from outputDir, returning an empty
+// index if it doesn't exist yet or can't be parsed.
+func loadSyncIndex(outputDir string) syncIndex {
+
+	idx := syncIndex{}
+
+	b, err := os.ReadFile(filepath.Join(outputDir, syncIndexFilename))
+	if err != nil {
+		return idx
+	}
+
+	if err := json.Unmarshal(b, &idx); err != nil {
+		return syncIndex{}
+	}
+
+	return idx
+}
+
+// saveSyncIndex writes the sidecar index back to outputDir.
+func saveSyncIndex(outputDir string, idx syncIndex) error {
+
+	b, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %s", syncIndexFilename, err.Error())
+	}
+
+	if err := os.WriteFile(filepath.Join(outputDir, syncIndexFilename), b, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %s", syncIndexFilename, err.Error())
+	}
+
+	return nil
+}
+
+// shouldSkipSync reports whether fo's output(s) are already up to date: all
+// of them exist, are newer than the source, and the recorded index entry
+// matches the source's current size, mtime and the requested resolution.
+func shouldSkipSync(fo FileOperation, idx syncIndex) bool {
+
+	si, err := os.Stat(fo.originPath)
+	if err != nil {
+		return false
+	}
+
+	for _, targetPath := range outputPaths(fo) {
+		ti, err := os.Stat(targetPath)
+		if err != nil {
+			return false
+		}
+
+		if ti.ModTime().Before(si.ModTime()) {
+			return false
+		}
+	}
+
+	syncIdxMu.Lock()
+	entry, ok := idx[fo.originPath]
+	syncIdxMu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	return entry.Resolution == resolution &&
+		entry.Size == si.Size() &&
+		entry.ModTime.Equal(si.ModTime())
+}
+
+// recordSyncIndexEntry stores fresh metadata for a FileOperation that was
+// just (re)processed.
+func recordSyncIndexEntry(fo FileOperation) {
+
+	si, err := os.Stat(fo.originPath)
+	if err != nil {
+		return
+	}
+
+	sum, err := sha256SourceFile(fo.originPath)
+	if err != nil {
+		return
+	}
+
+	entry := syncIndexEntry{
+		SourcePath: fo.originPath,
+		Size:       si.Size(),
+		ModTime:    si.ModTime(),
+		SHA256:     sum,
+		Resolution: resolution,
+	}
+
+	syncIdxMu.Lock()
+	syncIdx[fo.originPath] = entry
+	syncIdxMu.Unlock()
+}
+
+func sha256SourceFile(path string) (string, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cleanupOutputDir deletes files and directories under outputDir that have
+// no counterpart in keep, removing directories leaf-first once emptied.
+// Every candidate path is re-verified to be within outputDir before removal
+// as a defense-in-depth safety check.
+func cleanupOutputDir(outputDir string, keep map[string]bool) error {
+
+	var dirs []string
+
+	err := filepath.WalkDir(outputDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if p == outputDir || filepath.Base(p) == syncIndexFilename || filepath.Base(p) == "manifest.json" {
+			return nil
+		}
+
+		if !pathWithinDir(p, outputDir) {
+			return nil
+		}
+
+		if d.IsDir() {
+			dirs = append(dirs, p)
+			return nil
+		}
+
+		if keep[p] {
+			return nil
+		}
+
+		if err := os.Remove(p); err != nil {
+			return fmt.Errorf("error removing orphaned file %s: %s", p, err.Error())
+		}
+
+		if verbose {
+			fmt.Printf("removed orphaned file %s\n", p)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Leaf-first: deepest directories first, so parents are empty by the
+	// time we get to them.
+	sort.Slice(dirs, func(i, j int) bool {
+		return strings.Count(dirs[i], string(os.PathSeparator)) > strings.Count(dirs[j], string(os.PathSeparator))
+	})
+
+	for _, dir := range dirs {
+		if !pathWithinDir(dir, outputDir) {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			continue
+		}
+
+		if err := os.Remove(dir); err == nil && verbose {
+			fmt.Printf("removed empty directory %s\n", dir)
+		}
+	}
+
+	return nil
+}
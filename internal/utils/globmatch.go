@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MatchGlob reports whether path matches a doublestar-style glob pattern.
+// "**" matches zero or more path segments, "*" matches within a single
+// segment and "?" matches a single non-separator character. Both pattern
+// and path are normalized to forward slashes first, so patterns written on
+// Windows behave the same as on Unix.
+func MatchGlob(pattern, path string) bool {
+
+	return globPatternRegexp(pattern).MatchString(toForwardSlashes(path))
+}
+
+// toForwardSlashes replaces both the OS separator and any backslash with
+// "/", so Windows-style paths and patterns normalize on every platform.
+func toForwardSlashes(s string) string {
+
+	return strings.ReplaceAll(filepath.ToSlash(s), `\`, "/")
+}
+
+var globRegexpCache = map[string]*regexp.Regexp{}
+
+func globPatternRegexp(pattern string) *regexp.Regexp {
+
+	pattern = toForwardSlashes(pattern)
+
+	if re, ok := globRegexpCache[pattern]; ok {
+		return re
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for i := 0; i < len(pattern); {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 3
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i += 2
+		case pattern[i] == '*':
+			sb.WriteString("[^/]*")
+			i++
+		case pattern[i] == '?':
+			sb.WriteString("[^/]")
+			i++
+		case strings.ContainsRune(`.+()|^$[]{}\`, rune(pattern[i])):
+			sb.WriteString("\\" + string(pattern[i]))
+			i++
+		default:
+			sb.WriteByte(pattern[i])
+			i++
+		}
+	}
+
+	sb.WriteString("$")
+
+	re := regexp.MustCompile(sb.String())
+	globRegexpCache[pattern] = re
+
+	return re
+}
@@ -0,0 +1,45 @@
+package utils
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+
+	cases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.jpg", "photo.jpg", true},
+		{"*.jpg", "dir/photo.jpg", false},
+		{"**/*.jpg", "dir/photo.jpg", true},
+		{"**/*.jpg", "dir/sub/photo.jpg", true},
+		{"**/*.jpg", "photo.jpg", true},
+		{"**", "dir/sub/photo.jpg", true},
+		{"photo.???", "photo.jpg", true},
+		{"photo.???", "photo.jpeg", false},
+		{"dir/*.jpg", "dir/photo.jpg", true},
+		{"dir/*.jpg", "dir/sub/photo.jpg", false},
+		{"photo.jpg", "photo.png", false},
+		{"a.b+c", "a.b+c", true},
+		{"a.b+c", "axbyc", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.pattern+" vs "+c.path, func(t *testing.T) {
+			if got := MatchGlob(c.pattern, c.path); got != c.want {
+				t.Errorf("MatchGlob(%q, %q) = %v, want %v", c.pattern, c.path, got, c.want)
+			}
+		})
+	}
+}
+
+func TestMatchGlobWindowsPaths(t *testing.T) {
+
+	if !MatchGlob("dir/*.jpg", `dir\photo.jpg`) {
+		t.Error("expected backslash-separated path to match a forward-slash pattern")
+	}
+
+	if !MatchGlob(`dir\*.jpg`, "dir/photo.jpg") {
+		t.Error("expected backslash-separated pattern to match a forward-slash path")
+	}
+}